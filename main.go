@@ -6,26 +6,39 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/coreos/pkg/flagutil"
 	"github.com/google/go-github/github"
+	"github.com/sym3tri/go-github-pivotal-migrator/migrator"
 	"github.com/sym3tri/go-pivotaltracker/v5/pivotal"
 	"golang.org/x/oauth2"
 )
 
 var (
 	flags = struct {
-		owner    string
-		repos    flagutil.StringSliceFlag
-		ghToken  string
-		ptToken  string
-		ptProjId int
-		limit    int
-		dryRun   bool
+		owner         string
+		repos         flagutil.StringSliceFlag
+		ghToken       string
+		ptToken       string
+		ptProjId      int
+		limit         int
+		dryRun        bool
+		checkpoint    string
+		state         string
+		includePRs    bool
+		includeClosed bool
+		labelMap      string
+		workers       int
+		statusAddr    string
+		userMap       string
+		from          string
+		to            string
+		defaultBranch string
 	}{}
 
-	ghSvc *github.IssuesService
-	ptSvc *pivotal.StoryService
+	ghClient *github.Client
+	ptSvc    *pivotal.StoryService
 )
 
 func init() {
@@ -36,6 +49,34 @@ func init() {
 	flag.IntVar(&flags.ptProjId, "pt-proj-id", 0, "the Pivotal Project ID")
 	flag.IntVar(&flags.limit, "limit", 1000, "the max number of issues to attempt")
 	flag.BoolVar(&flags.dryRun, "dry-run", true, "print actions that would be taken")
+	flag.StringVar(&flags.checkpoint, "checkpoint", "migration-checkpoint.json", "path to the resumable checkpoint file")
+	flag.StringVar(&flags.state, "state", "open", "the github issue/PR state to migrate: open, closed, or all")
+	flag.BoolVar(&flags.includePRs, "include-prs", false, "also migrate pull requests as stories")
+	flag.BoolVar(&flags.includeClosed, "include-closed", false, "shorthand for -state=all")
+	flag.StringVar(&flags.labelMap, "label-map", "", "comma-separated github-label=pivotal-label pairs to rename labels on import")
+	flag.IntVar(&flags.workers, "workers", 4, "number of issues to migrate concurrently")
+	flag.StringVar(&flags.statusAddr, "status-addr", "", "if set, serve migration progress as JSON on this address, e.g. :8080")
+	flag.StringVar(&flags.userMap, "user-map", "", "path to a YAML file mapping github_login to a pivotal user id")
+	flag.StringVar(&flags.from, "from", "github", "migration source: github or pivotal")
+	flag.StringVar(&flags.to, "to", "pivotal", "migration destination: github or pivotal")
+	flag.StringVar(&flags.defaultBranch, "default-branch", "master", "the source repo's default branch, used to resolve relative image/link paths in migrated bodies")
+}
+
+// parseLabelMap turns "a=b,c=d" into {"a":"b","c":"d"}, skipping blank
+// and malformed entries.
+func parseLabelMap(s string) map[string]string {
+	m := map[string]string{}
+	if s == "" {
+		return m
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			continue
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m
 }
 
 func main() {
@@ -47,20 +88,55 @@ func main() {
 		log.Fatal("no github repos specified")
 	}
 
-	for _, repo := range repos {
-		fmt.Printf("Analysing repo: %s/%s\n", flags.owner, repo)
-		iss, _, err := ghSvc.ListByRepo(flags.owner, repo, &github.IssueListByRepoOptions{
-			State: "open",
-			ListOptions: github.ListOptions{
-				PerPage: flags.limit,
-			},
-		})
-		if err != nil {
-			log.Fatalf("failed to list issues for repo: %s, error: %v", repo, err)
-		}
-		fmt.Printf("found %d issues to migrate", len(iss))
-		for _, is := range iss {
-			migrateIssue(repo, is)
+	userMap, err := migrator.LoadUserMap(flags.userMap)
+	if err != nil {
+		log.Fatalf("failed to load user map: %v", err)
+	}
+
+	checkpointPath := flags.checkpoint
+	if flags.dryRun {
+		checkpointPath = ""
+	}
+	cp, err := migrator.LoadCheckpoint(checkpointPath)
+	if err != nil {
+		log.Fatalf("failed to load checkpoint: %v", err)
+	}
+
+	labelMap := parseLabelMap(flags.labelMap)
+
+	var uploader migrator.Uploader
+	if flags.dryRun {
+		uploader = migrator.NewPreviewUploader(os.Stdout, flags.to, labelMap, userMap, cp, flags.defaultBranch)
+	} else {
+		uploader = newUploader(flags.to, labelMap, userMap, cp)
+	}
+
+	state := flags.state
+	if flags.includeClosed {
+		state = "all"
+	}
+	downloader := newDownloader(flags.from, state)
+
+	m := migrator.New(downloader, uploader, cp)
+	m.IncludePullRequests = flags.includePRs
+	m.Workers = flags.workers
+	m.Limit = flags.limit
+
+	status := migrator.NewStatus()
+	m.Status = status
+	if flags.statusAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(flags.statusAddr, status); err != nil {
+				log.Printf("status server stopped: %v", err)
+			}
+		}()
+	}
+
+	if err := m.Run(flags.owner, repos); err != nil {
+		if _, ok := err.(migrator.Errors); ok {
+			log.Printf("migration finished with errors:\n%v", err)
+		} else {
+			log.Fatalf("migration failed: %v", err)
 		}
 	}
 
@@ -68,11 +144,39 @@ func main() {
 	os.Exit(0)
 }
 
+// newDownloader builds the Downloader for the given -from direction.
+func newDownloader(from, state string) migrator.Downloader {
+	switch from {
+	case "pivotal":
+		return migrator.NewPivotalDownloader(ptSvc, flags.ptProjId)
+	case "github":
+		d := migrator.NewGitHubDownloader(ghClient)
+		d.State = state
+		return d
+	default:
+		log.Fatalf("unknown -from %q: must be github or pivotal", from)
+		return nil
+	}
+}
+
+// newUploader builds the Uploader for the given -to direction.
+func newUploader(to string, labelMap map[string]string, userMap migrator.UserMap, cp *migrator.Checkpoint) migrator.Uploader {
+	switch to {
+	case "pivotal":
+		return migrator.NewPivotalUploader(ptSvc, flags.ptProjId, labelMap, userMap, cp, flags.defaultBranch)
+	case "github":
+		return migrator.NewGitHubUploader(ghClient, labelMap)
+	default:
+		log.Fatalf("unknown -to %q: must be github or pivotal", to)
+		return nil
+	}
+}
+
 func initClients() {
 	ptClient := pivotal.NewClient(flags.ptToken)
 	ptSvc = ptClient.Stories
 
-	ghClient := github.NewClient(
+	ghClient = github.NewClient(
 		func(ghToken string) *http.Client {
 			if ghToken == "" {
 				return nil
@@ -87,155 +191,4 @@ func initClients() {
 			)
 		}(flags.ghToken),
 	)
-	ghSvc = ghClient.Issues
-}
-
-func migrateIssue(repo string, is *github.Issue) {
-	fmt.Println("\n===== begin =====\n")
-
-	var newStory *pivotal.Story
-	var err error
-
-	storyReq := convertIssue(repo, is)
-	if flags.dryRun {
-		printIssue(is)
-		printStory(storyReq)
-	} else {
-		newStory, _, err = ptSvc.Create(flags.ptProjId, storyReq)
-		if err != nil {
-			log.Fatalf("error creating story: %v", err)
-		}
-	}
-
-	ghComments, _, err := ghSvc.ListComments(flags.owner, repo, *is.Number, &github.IssueListCommentsOptions{
-		ListOptions: github.ListOptions{
-			PerPage: 1000,
-		},
-	})
-	if err != nil {
-		log.Fatalf("failed to list gh comments for repo: %s, issue %d: error: %v", repo, *is.Number, err)
-	}
-
-	fmt.Printf("found %d comments for issue number: %d", len(ghComments), *is.Number)
-	for _, cm := range ghComments {
-		commentReq := convertComment(cm)
-		if flags.dryRun {
-			printIssueComment(cm)
-			printStoryComment(commentReq)
-		} else {
-			_, _, err := ptSvc.AddComment(flags.ptProjId, newStory.Id, commentReq)
-			if err != nil {
-				log.Fatalf("error creating comment: %v", err)
-			}
-		}
-	}
-
-	fmt.Println("\n===== end =====\n")
-}
-
-func convertIssue(repo string, is *github.Issue) *pivotal.StoryRequest {
-	labels := []*pivotal.Label{
-		&pivotal.Label{Name: "github-migrated"},
-		&pivotal.Label{Name: fmt.Sprintf("github-repo/%s", repo)},
-	}
-
-	bodyFmt := "%s\n```"
-	bodyFmt += `
-Migrated from Github
-Created: %s
-Labels: %q
-`
-	bodyFmt += "```\n\n"
-
-	body := fmt.Sprintf(bodyFmt, *is.HTMLURL, *is.CreatedAt, is.Labels)
-	body += *is.Body
-
-	sr := &pivotal.StoryRequest{
-		Name:        *is.Title,
-		Description: body,
-		Labels:      &labels,
-		Type:        pivotal.StoryTypeFeature,
-		State:       pivotal.StoryStateUnscheduled,
-	}
-
-	return sr
-}
-
-func convertComment(cm *github.IssueComment) *pivotal.Comment {
-	bodyFmt := "%s\n```"
-	bodyFmt += `
-Migrated from Github
-Created: %s
-Author: %s
-`
-	bodyFmt += "```\n\n"
-
-	body := fmt.Sprintf(bodyFmt, *cm.HTMLURL, *cm.CreatedAt, *cm.User.Login)
-	body += *cm.Body
-
-	c := &pivotal.Comment{
-		Text: body,
-	}
-
-	return c
-}
-
-func printIssue(is *github.Issue) {
-	fmtStr := `
---- issue ---
-Number: %d
-Title: %s
-URL: %s
-Created: %s
-Labels: %q
---- /issue ---
-`
-	fmt.Printf(fmtStr, *is.Number, *is.Title, *is.HTMLURL, *is.CreatedAt, is.Labels)
-}
-
-func printStory(sr *pivotal.StoryRequest) {
-	labels := []string{}
-	for _, s := range *sr.Labels {
-		labels = append(labels, s.Name)
-	}
-
-	fmtStr := `
---- story ---
-Name: %s
-Description: %s
-Type: %s
-State: %s
-Labels: %q
---- /story ---
-`
-	fmt.Printf(fmtStr, sr.Name, trunc(sr.Description), sr.Type, sr.State, labels)
-}
-
-func printIssueComment(cm *github.IssueComment) {
-	fmtStr := `
---- issue comment ---
-Author: %s
-Created: %s
-Body: %s
---- /issue comment ---
-`
-
-	fmt.Printf(fmtStr, *cm.User.Login, *cm.CreatedAt, trunc(*cm.Body))
-}
-
-func printStoryComment(cm *pivotal.Comment) {
-	fmtStr := `
---- story comment ---
-Text: %s
---- /story comment ---
-`
-	fmt.Printf(fmtStr, trunc(cm.Text))
-}
-
-func trunc(s string) string {
-	if len(s) < 255 {
-		return s
-	}
-
-	return s[0:255]
 }
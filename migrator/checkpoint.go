@@ -0,0 +1,117 @@
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// checkpointKey identifies a single migrated issue within a repo.
+type checkpointKey struct {
+	Repo        string
+	IssueNumber int
+}
+
+func (k checkpointKey) String() string {
+	return fmt.Sprintf("%s#%d", k.Repo, k.IssueNumber)
+}
+
+// Checkpoint is a JSON-on-disk record of which issues have already been
+// migrated, keyed by repo and issue number, so a re-run can skip work
+// that already landed on the destination instead of recreating it.
+type Checkpoint struct {
+	path string
+	mu   sync.Mutex
+	// Done maps "owner/repo#issue" to the id assigned by the destination.
+	Done map[string]int `json:"done"`
+	// Comments maps "owner/repo#issue" to how many of that issue's
+	// comments (in Downloader.Comments order) have already been
+	// uploaded, so a re-run of an already-done issue only replays the
+	// comments it hasn't posted yet instead of the whole list.
+	Comments map[string]int `json:"comments"`
+}
+
+// LoadCheckpoint reads path if it exists, or returns an empty Checkpoint
+// that will be written to path on Save.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{path: path, Done: map[string]int{}, Comments: map[string]int{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %s: %v", path, err)
+	}
+	cp.path = path
+	if cp.Comments == nil {
+		cp.Comments = map[string]int{}
+	}
+	return cp, nil
+}
+
+// IsDone reports whether repo/issueNumber has already been migrated, and
+// the id it was assigned on the destination.
+func (c *Checkpoint) IsDone(repo string, issueNumber int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, ok := c.Done[checkpointKey{repo, issueNumber}.String()]
+	return id, ok
+}
+
+// MarkDone records that repo/issueNumber was migrated to the given
+// destination id, then persists the checkpoint to disk.
+func (c *Checkpoint) MarkDone(repo string, issueNumber int, id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Done[checkpointKey{repo, issueNumber}.String()] = id
+	return c.save()
+}
+
+// CommentsUploaded reports how many of repo/issueNumber's comments have
+// already been uploaded.
+func (c *Checkpoint) CommentsUploaded(repo string, issueNumber int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Comments[checkpointKey{repo, issueNumber}.String()]
+}
+
+// MarkCommentUploaded records in memory that the count'th comment
+// (1-indexed) on repo/issueNumber has been uploaded, so a re-run resumes
+// from count instead of reposting it. It does not itself persist to
+// disk - call Persist once the caller is done recording progress for the
+// issue, since a comment-heavy issue would otherwise serialize its
+// workers behind a full checkpoint rewrite per comment.
+func (c *Checkpoint) MarkCommentUploaded(repo string, issueNumber int, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Comments[checkpointKey{repo, issueNumber}.String()] = count
+}
+
+// Persist writes the checkpoint to disk, picking up whatever Done/Comments
+// progress has been recorded since the last Persist.
+func (c *Checkpoint) Persist() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+// save writes the checkpoint to disk. Callers must hold c.mu.
+func (c *Checkpoint) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, data, 0644)
+}
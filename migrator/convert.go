@@ -0,0 +1,37 @@
+package migrator
+
+// Converter holds the label-renaming and author-attribution rules that
+// are identical regardless of which direction a migration runs in, so
+// PivotalUploader, GitHubUploader, and PreviewUploader can all defer to
+// the same logic instead of re-implementing it.
+type Converter interface {
+	// Attribution looks up the destination user an author login should
+	// be credited to.
+	Attribution(login string) (UserMapEntry, bool)
+	// MappedLabel renames a label for the destination, e.g. via
+	// -label-map. Labels with no entry pass through unchanged.
+	MappedLabel(name string) string
+}
+
+type converter struct {
+	labelMap map[string]string
+	userMap  UserMap
+}
+
+// NewConverter builds a Converter from the label and user maps loaded
+// from CLI flags. Either may be nil.
+func NewConverter(labelMap map[string]string, userMap UserMap) Converter {
+	return &converter{labelMap: labelMap, userMap: userMap}
+}
+
+func (c *converter) Attribution(login string) (UserMapEntry, bool) {
+	entry, ok := c.userMap[login]
+	return entry, ok
+}
+
+func (c *converter) MappedLabel(name string) string {
+	if mapped, ok := c.labelMap[name]; ok {
+		return mapped
+	}
+	return name
+}
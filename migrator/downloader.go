@@ -0,0 +1,13 @@
+package migrator
+
+// Downloader pulls migration data out of a source system, repo by repo.
+// Implementations are free to page internally; callers should assume a
+// single call may do multiple round trips.
+type Downloader interface {
+	Labels(repo Repository) ([]Label, error)
+	Milestones(repo Repository) ([]Milestone, error)
+	Issues(repo Repository) ([]Issue, error)
+	Comments(repo Repository, issueNumber int) ([]Comment, error)
+	PullRequests(repo Repository) ([]PullRequest, error)
+	Releases(repo Repository) ([]Release, error)
+}
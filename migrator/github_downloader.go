@@ -0,0 +1,333 @@
+package migrator
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// GitHubDownloader implements Downloader on top of the go-github client,
+// extracted from the ad-hoc ghSvc calls that used to live in main.go.
+type GitHubDownloader struct {
+	Client *github.Client
+	// State is the GitHub issue/PR state to list: "open", "closed", or
+	// "all". Defaults to "open" via NewGitHubDownloader.
+	State string
+}
+
+// NewGitHubDownloader wraps client in a Downloader that lists open
+// issues and pull requests only.
+func NewGitHubDownloader(client *github.Client) *GitHubDownloader {
+	return &GitHubDownloader{Client: client, State: "open"}
+}
+
+func (d *GitHubDownloader) Labels(repo Repository) ([]Label, error) {
+	var labels []Label
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		var page []*github.Label
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.Issues.ListLabels(repo.Owner, repo.Name, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list labels for %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+
+		for _, l := range page {
+			labels = append(labels, Label{Name: *l.Name})
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return labels, nil
+}
+
+func (d *GitHubDownloader) Milestones(repo Repository) ([]Milestone, error) {
+	var milestones []Milestone
+	opts := &github.MilestoneListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		var page []*github.Milestone
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.Issues.ListMilestones(repo.Owner, repo.Name, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestones for %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+
+		for _, m := range page {
+			milestones = append(milestones, Milestone{
+				Number: *m.Number,
+				Title:  *m.Title,
+				State:  *m.State,
+			})
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return milestones, nil
+}
+
+func (d *GitHubDownloader) Issues(repo Repository) ([]Issue, error) {
+	var issues []Issue
+	opts := &github.IssueListByRepoOptions{
+		State:       d.State,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		var page []*github.Issue
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.Issues.ListByRepo(repo.Owner, repo.Name, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues for %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+
+		for _, is := range page {
+			if is.PullRequestLinks != nil {
+				continue
+			}
+			issues = append(issues, convertGitHubIssue(is))
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+func (d *GitHubDownloader) Comments(repo Repository, issueNumber int) ([]Comment, error) {
+	var comments []Comment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		var page []*github.IssueComment
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.Issues.ListComments(repo.Owner, repo.Name, issueNumber, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments for %s/%s issue %d: %v", repo.Owner, repo.Name, issueNumber, err)
+		}
+
+		for _, cm := range page {
+			comments = append(comments, Comment{
+				IssueNumber: issueNumber,
+				Body:        *cm.Body,
+				Author:      ghLogin(cm.User),
+				HTMLURL:     *cm.HTMLURL,
+				CreatedAt:   *cm.CreatedAt,
+			})
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return comments, nil
+}
+
+func (d *GitHubDownloader) PullRequests(repo Repository) ([]PullRequest, error) {
+	var prs []PullRequest
+	opts := &github.PullRequestListOptions{
+		State:       d.State,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		var page []*github.PullRequest
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.PullRequests.List(repo.Owner, repo.Name, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests for %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+
+		for _, pr := range page {
+			issue := Issue{
+				Number:    *pr.Number,
+				Title:     *pr.Title,
+				HTMLURL:   *pr.HTMLURL,
+				State:     *pr.State,
+				Author:    ghLogin(pr.User),
+				CreatedAt: *pr.CreatedAt,
+			}
+			if pr.Body != nil {
+				issue.Body = *pr.Body
+			}
+			diffURL := ""
+			if pr.DiffURL != nil {
+				diffURL = *pr.DiffURL
+			}
+			prs = append(prs, PullRequest{Issue: issue, DiffURL: diffURL})
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return prs, nil
+}
+
+func (d *GitHubDownloader) Releases(repo Repository) ([]Release, error) {
+	var releases []Release
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		var page []*github.RepositoryRelease
+		var resp *github.Response
+		err := retryGitHub(func() error {
+			var e error
+			page, resp, e = d.Client.Repositories.ListReleases(repo.Owner, repo.Name, opts)
+			return e
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases for %s/%s: %v", repo.Owner, repo.Name, err)
+		}
+
+		for _, r := range page {
+			rel := Release{TagName: *r.TagName, Name: *r.Name}
+			if r.Body != nil {
+				rel.Body = *r.Body
+			}
+			if r.CreatedAt != nil {
+				rel.CreatedAt = *r.CreatedAt
+			}
+			releases = append(releases, rel)
+		}
+
+		if waitForRateLimit(resp); resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return releases, nil
+}
+
+// isRetryableGitHubError reports whether err looks like a transient
+// GitHub failure worth retrying: a 5xx server error or a 403 that
+// signals rate limiting.
+func isRetryableGitHubError(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	if !ok || errResp.Response == nil {
+		return false
+	}
+	code := errResp.Response.StatusCode
+	if code == 403 {
+		h := errResp.Response.Header
+		return h.Get("X-RateLimit-Remaining") == "0" || h.Get("Retry-After") != ""
+	}
+	return code >= 500
+}
+
+// waitForRateLimit sleeps until resp.Rate.Reset if the last response
+// reported no remaining requests, so the next call in the page loop
+// doesn't immediately hit the limit again.
+func waitForRateLimit(resp *github.Response) {
+	if resp == nil || resp.Rate.Remaining > 0 {
+		return
+	}
+	if wait := time.Until(resp.Rate.Reset.Time); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// retryGitHub wraps fn in retry's exponential backoff for transient 5xx
+// errors, same as any other GitHub call. But a 403 that reports the
+// rate limit is exhausted can mean a multi-minute wait until reset -
+// far longer than retry's backoff would ever sleep before giving up -
+// so that case waits out the reported reset time before letting retry
+// re-attempt the call.
+func retryGitHub(fn func() error) error {
+	return retry(func() error {
+		err := fn()
+		if errResp, ok := err.(*github.ErrorResponse); ok {
+			waitForRateLimitError(errResp.Response)
+		}
+		return err
+	}, isRetryableGitHubError)
+}
+
+// waitForRateLimitError sleeps until resp's X-RateLimit-Reset header if
+// resp reports the quota is exhausted (X-RateLimit-Remaining: 0). It
+// reads the raw headers rather than a parsed *github.Response, since an
+// error response only carries the underlying *http.Response.
+func waitForRateLimitError(resp *http.Response) {
+	if resp == nil || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	if wait := time.Until(time.Unix(resetUnix, 0)); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// ghLogin returns user's login, falling back to "ghost" for the nil
+// User GitHub returns on issues/comments/PRs authored by a deleted
+// account.
+func ghLogin(user *github.User) string {
+	if user == nil || user.Login == nil {
+		return "ghost"
+	}
+	return *user.Login
+}
+
+func convertGitHubIssue(is *github.Issue) Issue {
+	labels := make([]Label, 0, len(is.Labels))
+	for _, l := range is.Labels {
+		labels = append(labels, Label{Name: *l.Name})
+	}
+
+	issue := Issue{
+		Number:    *is.Number,
+		Title:     *is.Title,
+		HTMLURL:   *is.HTMLURL,
+		State:     *is.State,
+		Author:    ghLogin(is.User),
+		Labels:    labels,
+		CreatedAt: *is.CreatedAt,
+	}
+	if is.Body != nil {
+		issue.Body = *is.Body
+	}
+	if is.Milestone != nil {
+		issue.Milestone = &Milestone{
+			Number: *is.Milestone.Number,
+			Title:  *is.Milestone.Title,
+			State:  *is.Milestone.State,
+		}
+	}
+	return issue
+}
@@ -0,0 +1,144 @@
+package migrator
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/github"
+)
+
+// GitHubUploader implements Uploader on top of the go-github client, the
+// write-side counterpart to GitHubDownloader used for a -to=github
+// migration.
+type GitHubUploader struct {
+	Client *github.Client
+	Conv   Converter
+}
+
+// NewGitHubUploader wraps client in an Uploader, renaming labels per
+// labelMap (which may be nil) on the way in. GitHub has no API for
+// creating an issue/comment as another user, so author mapping from
+// userMap is never applied here; every issue and comment instead carries
+// an inline "Author:" attribution line, same as PivotalUploader falls
+// back to for logins with no userMap entry.
+func NewGitHubUploader(client *github.Client, labelMap map[string]string) *GitHubUploader {
+	return &GitHubUploader{Client: client, Conv: NewConverter(labelMap, nil)}
+}
+
+func (u *GitHubUploader) CreateLabel(repo Repository, label Label) error {
+	name := u.Conv.MappedLabel(label.Name)
+
+	var resp *github.Response
+	err := retryGitHub(func() error {
+		var e error
+		_, resp, e = u.Client.Issues.CreateLabel(repo.Owner, repo.Name, &github.Label{Name: &name})
+		return e
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode == 422 {
+			// Label already exists.
+			return nil
+		}
+		return fmt.Errorf("failed to create label %q for %s/%s: %v", name, repo.Owner, repo.Name, err)
+	}
+	return nil
+}
+
+func (u *GitHubUploader) CreateMilestone(repo Repository, milestone Milestone) (int, error) {
+	state := milestone.State
+	if state == "" {
+		state = "open"
+	}
+	req := &github.Milestone{Title: &milestone.Title, State: &state}
+
+	var m *github.Milestone
+	err := retryGitHub(func() error {
+		var e error
+		m, _, e = u.Client.Issues.CreateMilestone(repo.Owner, repo.Name, req)
+		return e
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create milestone %q for %s/%s: %v", milestone.Title, repo.Owner, repo.Name, err)
+	}
+	return *m.Number, nil
+}
+
+func (u *GitHubUploader) CreateRelease(repo Repository, release Release) error {
+	req := &github.RepositoryRelease{
+		TagName: &release.TagName,
+		Name:    &release.Name,
+		Body:    &release.Body,
+	}
+
+	err := retryGitHub(func() error {
+		_, _, e := u.Client.Repositories.CreateRelease(repo.Owner, repo.Name, req)
+		return e
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create release %q for %s/%s: %v", release.TagName, repo.Owner, repo.Name, err)
+	}
+	return nil
+}
+
+func (u *GitHubUploader) CreateIssue(repo Repository, issue Issue) (int, error) {
+	labels := make([]string, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		labels = append(labels, u.Conv.MappedLabel(l.Name))
+	}
+
+	body := githubIssueBody(issue)
+	req := &github.IssueRequest{
+		Title:  &issue.Title,
+		Body:   &body,
+		Labels: &labels,
+	}
+	if issue.Milestone != nil {
+		req.Milestone = &issue.Milestone.Number
+	}
+
+	var is *github.Issue
+	err := retryGitHub(func() error {
+		var e error
+		is, _, e = u.Client.Issues.Create(repo.Owner, repo.Name, req)
+		return e
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create issue for %s/%s#%d: %v", repo.Owner, repo.Name, issue.Number, err)
+	}
+
+	if issue.State == "closed" {
+		closed := "closed"
+		err := retryGitHub(func() error {
+			_, _, e := u.Client.Issues.Edit(repo.Owner, repo.Name, *is.Number, &github.IssueRequest{State: &closed})
+			return e
+		})
+		if err != nil {
+			return *is.Number, fmt.Errorf("created issue %s/%s#%d but failed to close it: %v", repo.Owner, repo.Name, *is.Number, err)
+		}
+	}
+
+	return *is.Number, nil
+}
+
+func (u *GitHubUploader) CreateComment(repo Repository, issueID int, comment Comment) error {
+	body := githubCommentBody(comment)
+	err := retryGitHub(func() error {
+		_, _, e := u.Client.Issues.CreateComment(repo.Owner, repo.Name, issueID, &github.IssueComment{Body: &body})
+		return e
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create comment on %s/%s#%d: %v", repo.Owner, repo.Name, issueID, err)
+	}
+	return nil
+}
+
+// githubIssueBody renders the issue body with an inline attribution
+// block, since the author can't be set via the GitHub API directly.
+func githubIssueBody(issue Issue) string {
+	bodyFmt := "```\nMigrated from Pivotal Tracker\nAuthor: %s\n```\n\n%s"
+	return fmt.Sprintf(bodyFmt, issue.Author, issue.Body)
+}
+
+func githubCommentBody(comment Comment) string {
+	bodyFmt := "```\nMigrated from Pivotal Tracker\nAuthor: %s\n```\n\n%s"
+	return fmt.Sprintf(bodyFmt, comment.Author, comment.Body)
+}
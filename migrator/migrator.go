@@ -0,0 +1,303 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// IssueError records a failure migrating a single issue, or - when
+// IssueNumber is 0 - a whole repo (e.g. listing its labels or issues
+// failed). Run collects these instead of aborting so one bad issue or
+// repo doesn't block the rest of the batch.
+type IssueError struct {
+	Repo        string
+	IssueNumber int
+	Err         error
+}
+
+func (e *IssueError) Error() string {
+	if e.IssueNumber == 0 {
+		return fmt.Sprintf("%s: %v", e.Repo, e.Err)
+	}
+	return fmt.Sprintf("%s#%d: %v", e.Repo, e.IssueNumber, e.Err)
+}
+
+// Errors aggregates the IssueErrors collected over a Run.
+type Errors []*IssueError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d issue(s) failed to migrate:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// defaultWorkers is how many issues Migrator migrates concurrently when
+// Workers is left unset.
+const defaultWorkers = 4
+
+// Migrator drives a Downloader/Uploader pair across a set of repos,
+// skipping any issue already recorded in its Checkpoint so a partially
+// completed run can resume without recreating stories.
+type Migrator struct {
+	Downloader Downloader
+	Uploader   Uploader
+	Checkpoint *Checkpoint
+	// IncludePullRequests also walks and migrates pull requests as
+	// issues. Defaults to false.
+	IncludePullRequests bool
+	// Workers is how many issues are migrated concurrently. Defaults to
+	// defaultWorkers.
+	Workers int
+	// Limit caps how many issues (across Issues and, if
+	// IncludePullRequests, PullRequests) are migrated per repo. Zero
+	// means no cap.
+	Limit int
+	// Status, if set, is updated as Run progresses so it can be polled
+	// or served over HTTP.
+	Status *Status
+}
+
+// New builds a Migrator. checkpoint may be nil, in which case nothing is
+// persisted and every issue is treated as not-yet-migrated.
+func New(d Downloader, u Uploader, checkpoint *Checkpoint) *Migrator {
+	if checkpoint == nil {
+		checkpoint = &Checkpoint{Done: map[string]int{}, Comments: map[string]int{}}
+	}
+	return &Migrator{Downloader: d, Uploader: u, Checkpoint: checkpoint, Workers: defaultWorkers}
+}
+
+// Run migrates every issue (and its comments) in each of repos. A
+// failure on one issue, or on a whole repo (e.g. its labels failed to
+// list), is recorded and the rest of the batch continues; Run returns a
+// non-nil Errors only if at least one issue or repo failed.
+func (m *Migrator) Run(owner string, repoNames []string) error {
+	var errs Errors
+	for _, name := range repoNames {
+		repo := Repository{Owner: owner, Name: name}
+		repoErrs, err := m.runRepo(repo)
+		if err != nil {
+			m.incErrors()
+			errs = append(errs, &IssueError{Repo: repo.Name, Err: err})
+			continue
+		}
+		errs = append(errs, repoErrs...)
+	}
+
+	if len(errs) > 0 {
+		m.setStage(StageFailed)
+		return errs
+	}
+	m.setStage(StageDone)
+	return nil
+}
+
+func (m *Migrator) runRepo(repo Repository) (Errors, error) {
+	m.setStage(StageImporting)
+	if err := m.migrateLabels(repo); err != nil {
+		return nil, err
+	}
+	if err := m.migrateMilestones(repo); err != nil {
+		return nil, err
+	}
+	if err := m.migrateReleases(repo); err != nil {
+		return nil, err
+	}
+
+	issues, err := m.Downloader.Issues(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.IncludePullRequests {
+		prs, err := m.Downloader.PullRequests(repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			issues = append(issues, pr.Issue)
+		}
+	}
+
+	if m.Limit > 0 && len(issues) > m.Limit {
+		issues = issues[:m.Limit]
+	}
+
+	m.setStage(StageMigrating)
+	return m.migrateIssues(repo, issues), nil
+}
+
+// migrateIssues fans issues out across m.Workers goroutines and
+// collects any per-issue failures.
+func (m *Migrator) migrateIssues(repo Repository, issues []Issue) Errors {
+	workers := m.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan Issue)
+	var mu sync.Mutex
+	var errs Errors
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for issue := range jobs {
+				if err := m.migrateIssue(repo, issue); err != nil {
+					m.incErrors()
+					mu.Lock()
+					errs = append(errs, &IssueError{Repo: repo.Name, IssueNumber: issue.Number, Err: err})
+					mu.Unlock()
+					continue
+				}
+				m.incIssues()
+			}
+		}()
+	}
+
+	for _, issue := range issues {
+		jobs <- issue
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+func (m *Migrator) migrateLabels(repo Repository) error {
+	labels, err := m.Downloader.Labels(repo)
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		if err := m.Uploader.CreateLabel(repo, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) migrateMilestones(repo Repository) error {
+	milestones, err := m.Downloader.Milestones(repo)
+	if err != nil {
+		return err
+	}
+	for _, milestone := range milestones {
+		if _, err := m.Uploader.CreateMilestone(repo, milestone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) migrateReleases(repo Repository) error {
+	releases, err := m.Downloader.Releases(repo)
+	if err != nil {
+		return err
+	}
+	for _, release := range releases {
+		if err := m.Uploader.CreateRelease(repo, release); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) migrateIssue(repo Repository, issue Issue) error {
+	key := fmt.Sprintf("%s/%s", repo.Owner, repo.Name)
+	if id, done := m.Checkpoint.IsDone(key, issue.Number); done {
+		comments, err := m.Downloader.Comments(repo, issue.Number)
+		if err != nil {
+			return err
+		}
+		return m.uploadComments(repo, key, issue.Number, id, comments)
+	}
+
+	// Pre-fetch comments from the source while the issue itself is
+	// being created on the destination, since the two round trips are
+	// independent until we have the uploaded issue's id.
+	type commentsResult struct {
+		comments []Comment
+		err      error
+	}
+	commentsCh := make(chan commentsResult, 1)
+	go func() {
+		comments, err := m.Downloader.Comments(repo, issue.Number)
+		commentsCh <- commentsResult{comments, err}
+	}()
+
+	id, err := m.Uploader.CreateIssue(repo, issue)
+	if err != nil {
+		return err
+	}
+
+	// Record the issue as done before its comments, so a re-run after a
+	// failed comment upload attaches the rest to this story instead of
+	// creating another one.
+	if err := m.Checkpoint.MarkDone(key, issue.Number, id); err != nil {
+		return err
+	}
+
+	cr := <-commentsCh
+	if cr.err != nil {
+		return cr.err
+	}
+
+	return m.uploadComments(repo, key, issue.Number, id, cr.comments)
+}
+
+// uploadComments uploads comments, skipping however many the checkpoint
+// already recorded as uploaded for repoKey/issueNumber and recording
+// progress in memory after each one. The checkpoint is persisted to disk
+// only once, after the batch, rather than per comment - a comment-heavy
+// issue would otherwise serialize every worker behind a full checkpoint
+// rewrite on each comment - so a re-run (of either a freshly migrated
+// issue whose comment upload was interrupted, or an already-done issue
+// revisited on a later run) resumes from the last persisted batch instead
+// of reposting comments that already landed on the destination.
+func (m *Migrator) uploadComments(repo Repository, repoKey string, issueNumber int, uploadedIssueID int, comments []Comment) error {
+	done := m.Checkpoint.CommentsUploaded(repoKey, issueNumber)
+	uploadErr := func() error {
+		for i := done; i < len(comments); i++ {
+			if err := m.Uploader.CreateComment(repo, uploadedIssueID, comments[i]); err != nil {
+				return err
+			}
+			m.incComments()
+			m.Checkpoint.MarkCommentUploaded(repoKey, issueNumber, i+1)
+		}
+		return nil
+	}()
+
+	if err := m.Checkpoint.Persist(); err != nil && uploadErr == nil {
+		return err
+	}
+	return uploadErr
+}
+
+func (m *Migrator) setStage(stage string) {
+	if m.Status != nil {
+		m.Status.setStage(stage)
+	}
+}
+
+func (m *Migrator) incIssues() {
+	if m.Status != nil {
+		m.Status.incIssues()
+	}
+}
+
+func (m *Migrator) incComments() {
+	if m.Status != nil {
+		m.Status.incComments()
+	}
+}
+
+func (m *Migrator) incErrors() {
+	if m.Status != nil {
+		m.Status.incErrors()
+	}
+}
@@ -0,0 +1,125 @@
+package migrator
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sym3tri/go-pivotaltracker/v5/pivotal"
+)
+
+// PivotalDownloader implements Downloader on top of a pivotal.StoryService,
+// the read-side counterpart to PivotalUploader used for a -from=pivotal
+// migration.
+type PivotalDownloader struct {
+	Stories   *pivotal.StoryService
+	ProjectID int
+}
+
+// NewPivotalDownloader wraps stories in a Downloader that reads every
+// story in projectID.
+func NewPivotalDownloader(stories *pivotal.StoryService, projectID int) *PivotalDownloader {
+	return &PivotalDownloader{Stories: stories, ProjectID: projectID}
+}
+
+// Labels returns nil: Pivotal has no standalone label resource to list,
+// so labels are created inline by GitHubUploader.CreateIssue instead.
+func (d *PivotalDownloader) Labels(repo Repository) ([]Label, error) {
+	return nil, nil
+}
+
+// Milestones returns nil: Pivotal has no native milestone concept, so a
+// story downloaded from it never carries one to recreate.
+func (d *PivotalDownloader) Milestones(repo Repository) ([]Milestone, error) {
+	return nil, nil
+}
+
+func (d *PivotalDownloader) Issues(repo Repository) ([]Issue, error) {
+	var stories []*pivotal.Story
+	err := retry(func() error {
+		var resp *http.Response
+		var e error
+		stories, resp, e = d.Stories.List(d.ProjectID, "")
+		return wrapPivotalError(resp, e)
+	}, isRetryablePivotalError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stories for project %d: %v", d.ProjectID, err)
+	}
+
+	issues := make([]Issue, 0, len(stories))
+	for _, s := range stories {
+		issues = append(issues, convertPivotalStory(s))
+	}
+	return issues, nil
+}
+
+func (d *PivotalDownloader) Comments(repo Repository, issueNumber int) ([]Comment, error) {
+	var pcomments []*pivotal.Comment
+	err := retry(func() error {
+		var resp *http.Response
+		var e error
+		pcomments, resp, e = d.Stories.Comments(d.ProjectID, issueNumber)
+		return wrapPivotalError(resp, e)
+	}, isRetryablePivotalError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments for story %d: %v", issueNumber, err)
+	}
+
+	comments := make([]Comment, 0, len(pcomments))
+	for _, c := range pcomments {
+		comments = append(comments, convertPivotalComment(issueNumber, c))
+	}
+	return comments, nil
+}
+
+// PullRequests returns nil: Pivotal has no pull request concept.
+func (d *PivotalDownloader) PullRequests(repo Repository) ([]PullRequest, error) {
+	return nil, nil
+}
+
+// Releases returns nil: Pivotal has no release concept.
+func (d *PivotalDownloader) Releases(repo Repository) ([]Release, error) {
+	return nil, nil
+}
+
+// convertPivotalStory maps a Pivotal story onto the source-agnostic Issue
+// type. Author is left as the raw Pivotal requester id, since turning it
+// back into a GitHub login needs a reverse user-map lookup that
+// GitHubUploader doesn't attempt; it instead falls back to the inline
+// attribution block, same as PivotalUploader does for unmapped authors.
+func convertPivotalStory(s *pivotal.Story) Issue {
+	labels := make([]Label, 0, len(s.Labels))
+	for _, l := range s.Labels {
+		labels = append(labels, Label{Name: l.Name})
+	}
+
+	state := "open"
+	if s.State == pivotal.StoryStateAccepted {
+		state = "closed"
+	}
+
+	issue := Issue{
+		Number:  s.Id,
+		Title:   s.Name,
+		Body:    s.Description,
+		HTMLURL: s.Url,
+		State:   state,
+		Author:  fmt.Sprintf("%d", s.RequestedById),
+		Labels:  labels,
+	}
+	if s.CreatedAt != nil {
+		issue.CreatedAt = *s.CreatedAt
+	}
+	return issue
+}
+
+func convertPivotalComment(storyID int, c *pivotal.Comment) Comment {
+	comment := Comment{
+		IssueNumber: storyID,
+		Body:        c.Text,
+		Author:      fmt.Sprintf("%d", c.PersonId),
+	}
+	if c.CreatedAt != nil {
+		comment.CreatedAt = *c.CreatedAt
+	}
+	return comment
+}
@@ -0,0 +1,227 @@
+package migrator
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sym3tri/go-github-pivotal-migrator/migrator/rewrite"
+	"github.com/sym3tri/go-pivotaltracker/v5/pivotal"
+)
+
+// PivotalUploader implements Uploader on top of a pivotal.StoryService,
+// extracted from the ptSvc calls that used to live in migrateIssue,
+// convertIssue, and convertComment.
+type PivotalUploader struct {
+	Stories   *pivotal.StoryService
+	ProjectID int
+	Conv      Converter
+	// UserMap maps a GitHub login to the id of the Pivotal user it
+	// should be attributed to. A login with no entry falls back to
+	// inline "Author:" attribution in the story/comment body.
+	UserMap UserMap
+	// Index resolves a #N issue reference to the story it was already
+	// migrated to in this run, for rewrite.Rewriter. Typically the same
+	// Checkpoint passed to Migrator.
+	Index rewrite.StoryIndex
+	// DefaultBranch is the branch relative image/link paths are resolved
+	// against when rewriting bodies. Defaults to "master" if empty.
+	DefaultBranch string
+}
+
+// NewPivotalUploader wraps stories in an Uploader targeting projectID,
+// renaming labels per labelMap and attributing authors per userMap
+// (either of which may be nil) on the way in. defaultBranch is the
+// branch relative image/link paths are resolved against; an empty
+// string falls back to "master".
+func NewPivotalUploader(stories *pivotal.StoryService, projectID int, labelMap map[string]string, userMap UserMap, index rewrite.StoryIndex, defaultBranch string) *PivotalUploader {
+	return &PivotalUploader{
+		Stories:       stories,
+		ProjectID:     projectID,
+		Conv:          NewConverter(labelMap, userMap),
+		UserMap:       userMap,
+		Index:         index,
+		DefaultBranch: defaultBranch,
+	}
+}
+
+// CreateLabel is a no-op: Pivotal has no standalone label resource, so
+// labels are attached directly to stories in CreateIssue.
+func (u *PivotalUploader) CreateLabel(repo Repository, label Label) error {
+	return nil
+}
+
+// CreateMilestone is a no-op: Pivotal has no native milestone concept,
+// so milestones are represented as labels on the story in CreateIssue.
+func (u *PivotalUploader) CreateMilestone(repo Repository, milestone Milestone) (int, error) {
+	return 0, nil
+}
+
+// CreateRelease is a no-op: Pivotal has no release concept to migrate a
+// GitHub release into.
+func (u *PivotalUploader) CreateRelease(repo Repository, release Release) error {
+	return nil
+}
+
+func (u *PivotalUploader) CreateIssue(repo Repository, issue Issue) (int, error) {
+	rw := rewrite.New(repo.Owner, repo.Name, u.DefaultBranch, mentionMap(u.UserMap), u.Index)
+	storyReq := issueToStoryRequest(repo, issue, u.Conv, rw)
+
+	var story *pivotal.Story
+	err := retry(func() error {
+		var resp *http.Response
+		var e error
+		story, resp, e = u.Stories.Create(u.ProjectID, storyReq)
+		return wrapPivotalError(resp, e)
+	}, isRetryablePivotalError)
+	if err != nil {
+		return 0, fmt.Errorf("error creating story for %s/%s#%d: %v", repo.Owner, repo.Name, issue.Number, err)
+	}
+	return story.Id, nil
+}
+
+func (u *PivotalUploader) CreateComment(repo Repository, issueID int, comment Comment) error {
+	rw := rewrite.New(repo.Owner, repo.Name, u.DefaultBranch, mentionMap(u.UserMap), u.Index)
+	commentReq := commentToPivotalComment(comment, u.Conv, rw)
+
+	err := retry(func() error {
+		_, resp, e := u.Stories.AddComment(u.ProjectID, issueID, commentReq)
+		return wrapPivotalError(resp, e)
+	}, isRetryablePivotalError)
+	if err != nil {
+		return fmt.Errorf("error creating comment on story %d: %v", issueID, err)
+	}
+	return nil
+}
+
+// pivotalRetryableError marks an error as worth retrying, distinguishing
+// a transient 5xx/403 response from a permanent failure such as bad
+// input that happens to also come back as a non-nil err.
+type pivotalRetryableError struct{ err error }
+
+func (e *pivotalRetryableError) Error() string { return e.err.Error() }
+
+// wrapPivotalError folds the HTTP response and error from a
+// pivotal.StoryService call into a single error, marking 5xx/403
+// responses as retryable.
+func wrapPivotalError(resp *http.Response, err error) error {
+	if resp != nil && (resp.StatusCode == http.StatusForbidden || resp.StatusCode >= 500) {
+		if err == nil {
+			err = fmt.Errorf("pivotal API returned status %d", resp.StatusCode)
+		}
+		return &pivotalRetryableError{err}
+	}
+	return err
+}
+
+func isRetryablePivotalError(err error) bool {
+	_, ok := err.(*pivotalRetryableError)
+	return ok
+}
+
+func issueToStoryRequest(repo Repository, issue Issue, conv Converter, rw *rewrite.Rewriter) *pivotal.StoryRequest {
+	labels := []*pivotal.Label{
+		{Name: "github-migrated"},
+		{Name: fmt.Sprintf("github-repo/%s", repo.Name)},
+	}
+
+	storyType := pivotal.StoryTypeFeature
+	for _, l := range issue.Labels {
+		if l.Name == "bug" {
+			storyType = pivotal.StoryTypeBug
+		}
+		labels = append(labels, &pivotal.Label{Name: conv.MappedLabel(l.Name)})
+	}
+	if issue.Milestone != nil {
+		labels = append(labels, &pivotal.Label{Name: fmt.Sprintf("milestone/%s", issue.Milestone.Title)})
+	}
+
+	state := pivotal.StoryStateUnscheduled
+	if issue.State == "closed" {
+		state = pivotal.StoryStateAccepted
+	}
+
+	createdAt := issue.CreatedAt
+	sr := &pivotal.StoryRequest{
+		Name:        issue.Title,
+		Description: issueBody(issue, conv, rw),
+		Labels:      &labels,
+		Type:        storyType,
+		State:       state,
+		CreatedAt:   &createdAt,
+	}
+
+	if entry, ok := conv.Attribution(issue.Author); ok {
+		sr.RequestedById = entry.PivotalID
+		sr.OwnedByIds = []int{entry.PivotalID}
+	}
+
+	return sr
+}
+
+// issueBody renders the story description, rewriting GitHub-specific
+// markdown (#N references, @mentions, relative links) along the way.
+// When issue.Author has an attribution, authorship is carried by
+// StoryRequest.RequestedById instead, so the body only needs the source
+// link and the original text; otherwise it falls back to the inline
+// attribution block.
+func issueBody(issue Issue, conv Converter, rw *rewrite.Rewriter) string {
+	body := rw.Rewrite(issue.Body)
+
+	if _, ok := conv.Attribution(issue.Author); ok {
+		return fmt.Sprintf("%s\n\n%s", issue.HTMLURL, body)
+	}
+
+	bodyFmt := "%s\n```"
+	bodyFmt += `
+Migrated from Github
+Created: %s
+Author: %s
+Labels: %q
+`
+	bodyFmt += "```\n\n"
+
+	return fmt.Sprintf(bodyFmt, issue.HTMLURL, issue.CreatedAt, issue.Author, issue.Labels) + body
+}
+
+func commentToPivotalComment(comment Comment, conv Converter, rw *rewrite.Rewriter) *pivotal.Comment {
+	createdAt := comment.CreatedAt
+	c := &pivotal.Comment{
+		Text:      commentBody(comment, conv, rw),
+		CreatedAt: &createdAt,
+	}
+
+	if entry, ok := conv.Attribution(comment.Author); ok {
+		c.PersonId = entry.PivotalID
+	}
+
+	return c
+}
+
+func commentBody(comment Comment, conv Converter, rw *rewrite.Rewriter) string {
+	body := rw.Rewrite(comment.Body)
+
+	if _, ok := conv.Attribution(comment.Author); ok {
+		return fmt.Sprintf("%s\n\n%s", comment.HTMLURL, body)
+	}
+
+	bodyFmt := "%s\n```"
+	bodyFmt += `
+Migrated from Github
+Created: %s
+Author: %s
+`
+	bodyFmt += "```\n\n"
+
+	return fmt.Sprintf(bodyFmt, comment.HTMLURL, comment.CreatedAt, comment.Author) + body
+}
+
+// mentionMap derives the rewrite package's login->display mapping from
+// the richer UserMap, used to render @login mentions in story/comment
+// bodies.
+func mentionMap(userMap UserMap) map[string]string {
+	mentions := make(map[string]string, len(userMap))
+	for login, entry := range userMap {
+		mentions[login] = fmt.Sprintf("**%s**", entry.Name)
+	}
+	return mentions
+}
@@ -0,0 +1,97 @@
+package migrator
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sym3tri/go-github-pivotal-migrator/migrator/rewrite"
+)
+
+// PreviewUploader implements Uploader by printing what CreateIssue and
+// CreateComment would send to the destination instead of calling its
+// API. It runs issues and comments through the same conversion the real
+// destination Uploader uses - PivotalUploader's label-map/user-map/GFM
+// rewrite pipeline for "pivotal", or GitHubUploader's inline attribution
+// body for "github" - so -dry-run output matches what a real run would
+// actually create. It may be called concurrently by the migrator's
+// worker pool.
+type PreviewUploader struct {
+	Out io.Writer
+	// To is the migration destination being previewed: "github" or
+	// "pivotal".
+	To      string
+	Conv    Converter
+	UserMap UserMap
+	// Index resolves a #N issue reference to the story it was already
+	// migrated to in this run, for rewrite.Rewriter. Typically the same
+	// Checkpoint passed to Migrator.
+	Index rewrite.StoryIndex
+	// DefaultBranch is the branch relative image/link paths are resolved
+	// against when rewriting bodies. Defaults to "master" if empty.
+	DefaultBranch string
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// NewPreviewUploader wraps out in an Uploader that previews a migration
+// to "to" ("github" or "pivotal"), applying the same label/user mapping
+// and link rewriting the real Uploader for that destination would.
+func NewPreviewUploader(out io.Writer, to string, labelMap map[string]string, userMap UserMap, index rewrite.StoryIndex, defaultBranch string) *PreviewUploader {
+	return &PreviewUploader{
+		Out:           out,
+		To:            to,
+		Conv:          NewConverter(labelMap, userMap),
+		UserMap:       userMap,
+		Index:         index,
+		DefaultBranch: defaultBranch,
+	}
+}
+
+func (p *PreviewUploader) CreateLabel(repo Repository, label Label) error {
+	return nil
+}
+
+func (p *PreviewUploader) CreateMilestone(repo Repository, milestone Milestone) (int, error) {
+	return 0, nil
+}
+
+func (p *PreviewUploader) CreateRelease(repo Repository, release Release) error {
+	if p.To == "github" {
+		fmt.Fprintf(p.Out, "\n--- release %s/%s %s ---\nName: %s\nBody:\n%s\n--- /release ---\n",
+			repo.Owner, repo.Name, release.TagName, release.Name, release.Body)
+	}
+	return nil
+}
+
+func (p *PreviewUploader) CreateIssue(repo Repository, issue Issue) (int, error) {
+	if p.To == "github" {
+		fmt.Fprintf(p.Out, "\n--- issue %s/%s#%d ---\nTitle: %s\nState: %s\nBody:\n%s\n--- /issue ---\n",
+			repo.Owner, repo.Name, issue.Number, issue.Title, issue.State, githubIssueBody(issue))
+	} else {
+		rw := rewrite.New(repo.Owner, repo.Name, p.DefaultBranch, mentionMap(p.UserMap), p.Index)
+		storyReq := issueToStoryRequest(repo, issue, p.Conv, rw)
+		fmt.Fprintf(p.Out, "\n--- issue %s/%s#%d ---\nName: %s\nType: %s\nState: %s\nDescription:\n%s\n--- /issue ---\n",
+			repo.Owner, repo.Name, issue.Number, storyReq.Name, storyReq.Type, storyReq.State, storyReq.Description)
+	}
+
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.mu.Unlock()
+	return id, nil
+}
+
+func (p *PreviewUploader) CreateComment(repo Repository, issueID int, comment Comment) error {
+	if p.To == "github" {
+		fmt.Fprintf(p.Out, "\n--- comment on %s/%s#%d ---\n%s\n--- /comment ---\n",
+			repo.Owner, repo.Name, issueID, githubCommentBody(comment))
+		return nil
+	}
+
+	rw := rewrite.New(repo.Owner, repo.Name, p.DefaultBranch, mentionMap(p.UserMap), p.Index)
+	commentReq := commentToPivotalComment(comment, p.Conv, rw)
+	fmt.Fprintf(p.Out, "\n--- comment on story %d ---\n%s\n--- /comment ---\n", issueID, commentReq.Text)
+	return nil
+}
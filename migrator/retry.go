@@ -0,0 +1,28 @@
+package migrator
+
+import "time"
+
+const (
+	retryAttempts  = 5
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// retry calls fn up to retryAttempts times with exponential backoff
+// (starting at retryBaseDelay, capped at retryMaxDelay), stopping as
+// soon as fn succeeds or shouldRetry reports the error isn't transient.
+func retry(fn func() error, shouldRetry func(error) bool) error {
+	delay := retryBaseDelay
+	var err error
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		if err = fn(); err == nil || !shouldRetry(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+	return err
+}
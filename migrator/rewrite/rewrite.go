@@ -0,0 +1,112 @@
+// Package rewrite translates GitHub-flavored markdown constructs that
+// Pivotal doesn't understand - #N issue references, @user mentions, and
+// relative link/image paths - into forms that survive the move.
+package rewrite
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// StoryIndex looks up the Pivotal story id a GitHub issue was migrated
+// to earlier in the same run. migrator.Checkpoint satisfies this.
+type StoryIndex interface {
+	IsDone(repo string, issueNumber int) (int, bool)
+}
+
+var (
+	crossRepoRefPattern = regexp.MustCompile(`\b([\w-]+/[\w-]+)#(\d+)\b`)
+	issueRefPattern     = regexp.MustCompile(`#(\d+)\b`)
+	mentionPattern      = regexp.MustCompile(`(^|[^\w@/])@([A-Za-z0-9][A-Za-z0-9-]*)\b`)
+	relativeLinkPattern = regexp.MustCompile(`(!?\[[^\]]*\]\()([^)\s]+)(\))`)
+	absolutePathPattern = regexp.MustCompile(`^(https?://|#|mailto:)`)
+)
+
+// defaultBranch is used for relative link rewriting when the caller
+// doesn't know the repo's actual default branch.
+const defaultBranch = "master"
+
+// Rewriter rewrites issue and comment bodies for a single GitHub
+// owner/repo being migrated into a Pivotal project.
+type Rewriter struct {
+	Owner string
+	Repo  string
+	// DefaultBranch is the branch relative image/link paths are resolved
+	// against, e.g. "main" or "master". Defaults to "master" if empty.
+	DefaultBranch string
+	// UserMap maps a GitHub login to the Pivotal mention it should
+	// become, e.g. "@alice-pivotal". Logins with no entry are rewritten
+	// to a markdown link back to their GitHub profile instead.
+	UserMap map[string]string
+	// Index resolves #N to an already-migrated Pivotal story id. May be
+	// nil, in which case every #N expands to a GitHub URL.
+	Index StoryIndex
+}
+
+// New builds a Rewriter for owner/repo. defaultBranch is the branch
+// relative image/link paths are resolved against; an empty string falls
+// back to "master".
+func New(owner, repo, defaultBranch string, userMap map[string]string, index StoryIndex) *Rewriter {
+	return &Rewriter{Owner: owner, Repo: repo, DefaultBranch: defaultBranch, UserMap: userMap, Index: index}
+}
+
+// Rewrite applies all rewrite rules to body and returns the result.
+func (r *Rewriter) Rewrite(body string) string {
+	body = r.rewriteCrossRepoRefs(body)
+	body = r.rewriteIssueRefs(body)
+	body = r.rewriteMentions(body)
+	body = r.rewriteRelativeLinks(body)
+	return body
+}
+
+func (r *Rewriter) rewriteCrossRepoRefs(body string) string {
+	return crossRepoRefPattern.ReplaceAllString(body, "https://github.com/$1/issues/$2")
+}
+
+func (r *Rewriter) rewriteIssueRefs(body string) string {
+	return issueRefPattern.ReplaceAllStringFunc(body, func(match string) string {
+		sub := issueRefPattern.FindStringSubmatch(match)
+		number := 0
+		fmt.Sscanf(sub[1], "%d", &number)
+
+		if r.Index != nil {
+			if storyID, ok := r.Index.IsDone(fmt.Sprintf("%s/%s", r.Owner, r.Repo), number); ok {
+				return fmt.Sprintf("#%d", storyID)
+			}
+		}
+		return fmt.Sprintf("https://github.com/%s/%s/issues/%d", r.Owner, r.Repo, number)
+	})
+}
+
+func (r *Rewriter) rewriteMentions(body string) string {
+	return mentionPattern.ReplaceAllStringFunc(body, func(match string) string {
+		sub := mentionPattern.FindStringSubmatch(match)
+		prefix, login := sub[1], sub[2]
+
+		if mapped, ok := r.UserMap[login]; ok {
+			return prefix + mapped
+		}
+		return fmt.Sprintf("%s[@%s](https://github.com/%s)", prefix, login, login)
+	})
+}
+
+func (r *Rewriter) rewriteRelativeLinks(body string) string {
+	return relativeLinkPattern.ReplaceAllStringFunc(body, func(match string) string {
+		sub := relativeLinkPattern.FindStringSubmatch(match)
+		prefix, path, suffix := sub[1], sub[2], sub[3]
+
+		if absolutePathPattern.MatchString(path) {
+			return match
+		}
+
+		trimmed := path
+		for len(trimmed) > 1 && trimmed[:2] == "./" {
+			trimmed = trimmed[2:]
+		}
+		branch := r.DefaultBranch
+		if branch == "" {
+			branch = defaultBranch
+		}
+		return fmt.Sprintf("%s%s%s", prefix, fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", r.Owner, r.Repo, branch, trimmed), suffix)
+	})
+}
@@ -0,0 +1,112 @@
+package rewrite
+
+import "testing"
+
+// fakeIndex is a minimal StoryIndex for a single already-migrated issue.
+type fakeIndex struct {
+	repo        string
+	issueNumber int
+	storyID     int
+}
+
+func (f fakeIndex) IsDone(repo string, issueNumber int) (int, bool) {
+	if repo == f.repo && issueNumber == f.issueNumber {
+		return f.storyID, true
+	}
+	return 0, false
+}
+
+func TestRewriteCrossRepoRefs(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("see acme/widgets#42 for details")
+	want := "see https://github.com/acme/widgets/issues/42 for details"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteIssueRefsNoIndex(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("fixes #7")
+	want := "fixes https://github.com/acme/widgets/issues/7"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteIssueRefsResolvedByIndex(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, fakeIndex{repo: "acme/widgets", issueNumber: 7, storyID: 123})
+	got := rw.Rewrite("fixes #7")
+	want := "fixes #123"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteMentionsMapped(t *testing.T) {
+	rw := New("acme", "widgets", "", map[string]string{"alice": "**Alice A.**"}, nil)
+	got := rw.Rewrite("thanks @alice for the fix")
+	want := "thanks **Alice A.** for the fix"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteMentionsUnmapped(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("thanks @bob for the fix")
+	want := "thanks [@bob](https://github.com/bob) for the fix"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteRelativeLinksDefaultBranchFallback(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("![screenshot](./img/shot.png)")
+	want := "![screenshot](https://github.com/acme/widgets/blob/master/img/shot.png)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteRelativeLinksExplicitBranch(t *testing.T) {
+	rw := New("acme", "widgets", "main", nil, nil)
+	got := rw.Rewrite("![screenshot](./img/shot.png)")
+	want := "![screenshot](https://github.com/acme/widgets/blob/main/img/shot.png)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteRelativeLinksLeavesAbsolutePathsAlone(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("[site](https://example.com/x)")
+	want := "[site](https://example.com/x)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteRelativeLinksPreservesFragment(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("[setup](./docs/readme.md#install)")
+	want := "[setup](https://github.com/acme/widgets/blob/master/docs/readme.md#install)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestRewriteCrossRepoRefThenBareIssueRef is the interaction case: a
+// cross-repo reference immediately followed by a bare #N reference to
+// the same issue. rewriteCrossRepoRefs must consume the "#" from the
+// first occurrence entirely (leaving no literal "#" behind) so the
+// later rewriteIssueRefs pass doesn't also trip on it.
+func TestRewriteCrossRepoRefThenBareIssueRef(t *testing.T) {
+	rw := New("acme", "widgets", "", nil, nil)
+	got := rw.Rewrite("acme/widgets#42 also see #42")
+	want := "https://github.com/acme/widgets/issues/42 also see https://github.com/acme/widgets/issues/42"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package migrator
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Migration stages reported by Status.
+const (
+	StageImporting = "Importing"
+	StageMigrating = "Migrating"
+	StageFailed    = "Failed"
+	StageDone      = "Done"
+)
+
+// Status tracks the progress of a running Migrator so a long-running
+// migration can be observed from the outside, e.g. via ServeHTTP.
+type Status struct {
+	mu       sync.Mutex
+	stage    string
+	issues   int
+	comments int
+	errors   int
+}
+
+// NewStatus returns a Status in StageImporting.
+func NewStatus() *Status {
+	return &Status{stage: StageImporting}
+}
+
+func (s *Status) setStage(stage string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stage = stage
+}
+
+func (s *Status) incIssues() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issues++
+}
+
+func (s *Status) incComments() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.comments++
+}
+
+func (s *Status) incErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors++
+}
+
+// statusSnapshot is the JSON-serializable view of a Status.
+type statusSnapshot struct {
+	Stage    string `json:"stage"`
+	Issues   int    `json:"issues"`
+	Comments int    `json:"comments"`
+	Errors   int    `json:"errors"`
+}
+
+func (s *Status) snapshot() statusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return statusSnapshot{
+		Stage:    s.stage,
+		Issues:   s.issues,
+		Comments: s.comments,
+		Errors:   s.errors,
+	}
+}
+
+// ServeHTTP implements http.Handler, responding with the current status
+// as JSON so a -status-addr endpoint can expose migration progress.
+func (s *Status) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.snapshot())
+}
@@ -0,0 +1,59 @@
+package migrator
+
+import "time"
+
+// Repository identifies a single source/destination repository.
+type Repository struct {
+	Owner string
+	Name  string
+}
+
+// Label is a tag that can be attached to an Issue or PullRequest.
+type Label struct {
+	Name string
+}
+
+// Milestone groups issues together, mirroring a GitHub milestone.
+// PivotalUploader carries it as a "milestone/<title>" story label.
+type Milestone struct {
+	Number int
+	Title  string
+	State  string
+}
+
+// Issue is the source-agnostic representation of a GitHub issue or
+// Pivotal story.
+type Issue struct {
+	Number    int
+	Title     string
+	Body      string
+	HTMLURL   string
+	State     string
+	Author    string
+	Labels    []Label
+	Milestone *Milestone
+	CreatedAt time.Time
+}
+
+// Comment is a single comment on an Issue.
+type Comment struct {
+	IssueNumber int
+	Body        string
+	Author      string
+	HTMLURL     string
+	CreatedAt   time.Time
+}
+
+// PullRequest is an Issue that also carries a merge/diff URL.
+type PullRequest struct {
+	Issue
+	DiffURL string
+}
+
+// Release is a tagged GitHub release or equivalent.
+type Release struct {
+	TagName   string
+	Name      string
+	Body      string
+	CreatedAt time.Time
+}
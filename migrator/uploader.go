@@ -0,0 +1,12 @@
+package migrator
+
+// Uploader pushes migration data into a destination system. CreateIssue
+// returns the id assigned by the destination so comments can be attached
+// to it afterwards.
+type Uploader interface {
+	CreateLabel(repo Repository, label Label) error
+	CreateMilestone(repo Repository, milestone Milestone) (id int, err error)
+	CreateRelease(repo Repository, release Release) error
+	CreateIssue(repo Repository, issue Issue) (id int, err error)
+	CreateComment(repo Repository, issueID int, comment Comment) error
+}
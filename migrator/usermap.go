@@ -0,0 +1,44 @@
+package migrator
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// UserMapEntry is what a GitHub login maps to: the Pivotal user to
+// attribute stories/comments to, and the name to mention them by in
+// rewritten @login references.
+type UserMapEntry struct {
+	PivotalID int    `yaml:"pivotal_id"`
+	Name      string `yaml:"name"`
+}
+
+// UserMap maps a GitHub login to the Pivotal user it should be
+// attributed to, loaded from a YAML file such as:
+//
+//	alice:
+//	  pivotal_id: 500123
+//	  name: Alice Anderson
+//	bob:
+//	  pivotal_id: 500456
+//	  name: Bob Baker
+type UserMap map[string]UserMapEntry
+
+// LoadUserMap reads path, or returns an empty UserMap if path is "".
+func LoadUserMap(path string) (UserMap, error) {
+	um := UserMap{}
+	if path == "" {
+		return um, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user map %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, &um); err != nil {
+		return nil, fmt.Errorf("failed to parse user map %s: %v", path, err)
+	}
+	return um, nil
+}